@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+
+	apidiscoveryv2beta1 "k8s.io/api/apidiscovery/v2beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// aggregatedDiscoveryAccept is the content-type negotiated with the
+// apiserver to request a single-call, all-groups discovery document
+// instead of one response per GroupVersion. Servers that don't understand
+// it (Kubernetes < 1.26, or 1.26 without the feature gate) ignore the
+// Accept header and respond with the legacy APIGroupList shape at 200 OK
+// instead, which unmarshals into APIGroupDiscoveryList without error but
+// leaves it empty — so the response Content-Type, not unmarshal success,
+// is what tells the two apart. See isAggregatedDiscoveryResponse.
+const aggregatedDiscoveryAccept = "application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList"
+
+// negotiateViaAggregatedDiscovery resolves the first candidate in
+// allowedVersions whose registered GroupVersionKind the server advertises,
+// using a single call to the aggregated discovery endpoint rather than one
+// ServerResourcesForGroupVersion call per candidate. ok is false whenever
+// the aggregated document could not be obtained, wasn't actually served as
+// APIGroupDiscoveryList (old server, disabled feature gate), or failed to
+// parse, or contained a resource with a nil ResponseKind — so the caller
+// can fall back to per-GV discovery exactly as client-go's own discovery
+// client does.
+func negotiateViaAggregatedDiscovery(client discovery.DiscoveryInterface, allowedVersions []IngressAPI) (result IngressAPI, ok bool) {
+	var contentType string
+	body, err := client.RESTClient().Get().
+		AbsPath("/apis").
+		SetHeader("Accept", aggregatedDiscoveryAccept).
+		Do(context.Background()).
+		ContentType(&contentType).
+		Raw()
+	if err != nil {
+		return OtherAPI, false
+	}
+
+	if !isAggregatedDiscoveryResponse(contentType) {
+		return OtherAPI, false
+	}
+
+	var list apidiscoveryv2beta1.APIGroupDiscoveryList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return OtherAPI, false
+	}
+
+	return pickFromAggregatedDiscovery(list, allowedVersions)
+}
+
+// isAggregatedDiscoveryResponse reports whether contentType is the
+// "as=APIGroupDiscoveryList" media type we asked for in
+// aggregatedDiscoveryAccept. A server that doesn't support aggregated
+// discovery ignores the Accept header and answers with a plain
+// "application/json" APIGroupList instead, which this rejects so the
+// per-GV fallback runs.
+func isAggregatedDiscoveryResponse(contentType string) bool {
+	mimeType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mimeType == "application/json" &&
+		params["g"] == "apidiscovery.k8s.io" &&
+		params["v"] == "v2beta1" &&
+		params["as"] == "APIGroupDiscoveryList"
+}
+
+// pickFromAggregatedDiscovery walks an already-parsed APIGroupDiscoveryList
+// and returns the first candidate in allowedVersions whose registered GVK is
+// present in it. ok is false iff any resource in the list has a nil
+// ResponseKind, matching the fallback conditions client-go itself uses.
+func pickFromAggregatedDiscovery(list apidiscoveryv2beta1.APIGroupDiscoveryList, allowedVersions []IngressAPI) (result IngressAPI, ok bool) {
+	supported := map[schema.GroupVersionKind]bool{}
+	for _, group := range list.Items {
+		for _, version := range group.Versions {
+			for _, res := range version.Resources {
+				if res.ResponseKind == nil {
+					return OtherAPI, false
+				}
+				gvk := schema.GroupVersionKind{Group: group.Name, Version: version.Version, Kind: res.ResponseKind.Kind}
+				supported[gvk] = true
+			}
+		}
+	}
+
+	for _, candidate := range allowedVersions {
+		c, ok := lookupCandidate(candidate)
+		if !ok {
+			continue
+		}
+		if supported[c.gvk] {
+			return candidate, true
+		}
+	}
+	return OtherAPI, true
+}
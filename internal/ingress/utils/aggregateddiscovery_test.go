@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"testing"
+
+	apidiscoveryv2beta1 "k8s.io/api/apidiscovery/v2beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsAggregatedDiscoveryResponse(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		want        bool
+	}{
+		{
+			name:        "aggregated discovery content type",
+			contentType: "application/json;g=apidiscovery.k8s.io;v=v2beta1;as=APIGroupDiscoveryList",
+			want:        true,
+		},
+		{
+			name:        "pre-1.27 server ignores Accept and answers with plain JSON",
+			contentType: "application/json",
+			want:        false,
+		},
+		{
+			name:        "unrelated structured content type",
+			contentType: "application/json;g=example.com;v=v1;as=Something",
+			want:        false,
+		},
+		{
+			name:        "empty content type",
+			contentType: "",
+			want:        false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAggregatedDiscoveryResponse(tt.contentType); got != tt.want {
+				t.Errorf("isAggregatedDiscoveryResponse(%q): got %t, want %t", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickFromAggregatedDiscovery(t *testing.T) {
+	withIngress := apidiscoveryv2beta1.APIGroupDiscoveryList{
+		Items: []apidiscoveryv2beta1.APIGroupDiscovery{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "networking.k8s.io"},
+				Versions: []apidiscoveryv2beta1.APIVersionDiscovery{
+					{
+						Version: "v1",
+						Resources: []apidiscoveryv2beta1.APIResourceDiscovery{
+							{Resource: "ingresses", ResponseKind: &metav1.GroupVersionKind{Kind: "Ingress"}},
+							{Resource: "ingressclasses", ResponseKind: &metav1.GroupVersionKind{Kind: "IngressClass"}},
+						},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "extensions"},
+				Versions: []apidiscoveryv2beta1.APIVersionDiscovery{
+					{
+						Version:   "v1beta1",
+						Resources: []apidiscoveryv2beta1.APIResourceDiscovery{{Resource: "ingresses", ResponseKind: &metav1.GroupVersionKind{Kind: "Ingress"}}},
+					},
+				},
+			},
+		},
+	}
+
+	nilKind := apidiscoveryv2beta1.APIGroupDiscoveryList{
+		Items: []apidiscoveryv2beta1.APIGroupDiscovery{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "networking.k8s.io"},
+				Versions: []apidiscoveryv2beta1.APIVersionDiscovery{
+					{
+						Version:   "v1",
+						Resources: []apidiscoveryv2beta1.APIResourceDiscovery{{Resource: "ingresses", ResponseKind: nil}},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range []struct {
+		name            string
+		list            apidiscoveryv2beta1.APIGroupDiscoveryList
+		allowedVersions []IngressAPI
+
+		wantResult IngressAPI
+		wantOK     bool
+	}{
+		{
+			name:            "picks the first allowed candidate the server supports",
+			list:            withIngress,
+			allowedVersions: []IngressAPI{NetworkingV1, ExtensionsV1beta1},
+			wantResult:      NetworkingV1,
+			wantOK:          true,
+		},
+		{
+			name:            "falls through to a later candidate",
+			list:            withIngress,
+			allowedVersions: []IngressAPI{NetworkingV1beta1, ExtensionsV1beta1},
+			wantResult:      ExtensionsV1beta1,
+			wantOK:          true,
+		},
+		{
+			name:            "no candidate supported, but the document was usable",
+			list:            withIngress,
+			allowedVersions: []IngressAPI{NetworkingV1beta1},
+			wantResult:      OtherAPI,
+			wantOK:          true,
+		},
+		{
+			name:            "nil ResponseKind triggers fallback",
+			list:            nilKind,
+			allowedVersions: []IngressAPI{NetworkingV1},
+			wantResult:      OtherAPI,
+			wantOK:          false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gotResult, gotOK := pickFromAggregatedDiscovery(tt.list, tt.allowedVersions)
+
+			if gotResult != tt.wantResult {
+				t.Errorf("pickFromAggregatedDiscovery result: got %v, want %v", gotResult, tt.wantResult)
+			}
+			if gotOK != tt.wantOK {
+				t.Errorf("pickFromAggregatedDiscovery ok: got %t, want %t", gotOK, tt.wantOK)
+			}
+		})
+	}
+}
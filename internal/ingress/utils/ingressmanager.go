@@ -0,0 +1,395 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// IngressManager performs CRUD operations on Ingress resources using
+// whichever API version was returned by NegotiateIngressAPI, so that callers
+// can be written once against networking/v1 types regardless of what the
+// apiserver actually serves.
+type IngressManager interface {
+	Get(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*networkingv1.Ingress, error)
+	List(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error)
+	Create(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.CreateOptions) (*networkingv1.Ingress, error)
+	Update(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.UpdateOptions) (*networkingv1.Ingress, error)
+	Delete(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error
+
+	// IngressPath returns the set of HTTP paths configured across all rules
+	// of obj, in the order they appear.
+	IngressPath(obj *networkingv1.Ingress) []string
+}
+
+// NewIngressManager builds the IngressManager that talks to the Ingress API
+// negotiated by NegotiateIngressAPI. The three built-in candidates dispatch
+// to their generated typed client and convert responses to the canonical
+// networking/v1 shape; any other registered candidate is built from its
+// Register-supplied factory, driven by dynamicClient.
+func NewIngressManager(client kubernetes.Interface, dynamicClient dynamic.Interface, api IngressAPI) (IngressManager, error) {
+	switch api {
+	case NetworkingV1:
+		return &v1IngressManager{client: client}, nil
+	case NetworkingV1beta1:
+		return &v1beta1IngressManager{client: client}, nil
+	case ExtensionsV1beta1:
+		return &extensionsV1beta1IngressManager{client: client}, nil
+	}
+
+	c, ok := lookupCandidate(api)
+	if !ok || c.factory == nil {
+		return nil, errors.Errorf("NewIngressManager: unsupported IngressAPI %v", api)
+	}
+	return c.factory(dynamicClient), nil
+}
+
+func ingressPath(obj *networkingv1.Ingress) []string {
+	var paths []string
+	for _, rule := range obj.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			paths = append(paths, p.Path)
+		}
+	}
+	return paths
+}
+
+// v1IngressManager talks directly to networking/v1, the canonical type, so
+// no conversion is necessary.
+type v1IngressManager struct {
+	client kubernetes.Interface
+}
+
+func (m *v1IngressManager) Get(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*networkingv1.Ingress, error) {
+	return m.client.NetworkingV1().Ingresses(namespace).Get(ctx, name, opts)
+}
+
+func (m *v1IngressManager) List(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error) {
+	return m.client.NetworkingV1().Ingresses(namespace).List(ctx, opts)
+}
+
+func (m *v1IngressManager) Create(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.CreateOptions) (*networkingv1.Ingress, error) {
+	return m.client.NetworkingV1().Ingresses(namespace).Create(ctx, ingress, opts)
+}
+
+func (m *v1IngressManager) Update(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.UpdateOptions) (*networkingv1.Ingress, error) {
+	return m.client.NetworkingV1().Ingresses(namespace).Update(ctx, ingress, opts)
+}
+
+func (m *v1IngressManager) Delete(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+	return m.client.NetworkingV1().Ingresses(namespace).Delete(ctx, name, opts)
+}
+
+func (m *v1IngressManager) IngressPath(obj *networkingv1.Ingress) []string {
+	return ingressPath(obj)
+}
+
+// v1beta1IngressManager talks to networking.k8s.io/v1beta1 and converts its
+// responses to networking/v1.
+type v1beta1IngressManager struct {
+	client kubernetes.Interface
+}
+
+func (m *v1beta1IngressManager) Get(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.NetworkingV1beta1().Ingresses(namespace).Get(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertV1beta1ToV1(ing), nil
+}
+
+func (m *v1beta1IngressManager) List(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error) {
+	list, err := m.client.NetworkingV1beta1().Ingresses(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := &networkingv1.IngressList{ListMeta: list.ListMeta}
+	for i := range list.Items {
+		out.Items = append(out.Items, *convertV1beta1ToV1(&list.Items[i]))
+	}
+	return out, nil
+}
+
+func (m *v1beta1IngressManager) Create(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.CreateOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.NetworkingV1beta1().Ingresses(namespace).Create(ctx, convertV1ToV1beta1(ingress), opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertV1beta1ToV1(ing), nil
+}
+
+func (m *v1beta1IngressManager) Update(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.UpdateOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.NetworkingV1beta1().Ingresses(namespace).Update(ctx, convertV1ToV1beta1(ingress), opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertV1beta1ToV1(ing), nil
+}
+
+func (m *v1beta1IngressManager) Delete(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+	return m.client.NetworkingV1beta1().Ingresses(namespace).Delete(ctx, name, opts)
+}
+
+func (m *v1beta1IngressManager) IngressPath(obj *networkingv1.Ingress) []string {
+	return ingressPath(obj)
+}
+
+// extensionsV1beta1IngressManager talks to the legacy extensions/v1beta1
+// Ingress API and converts its responses to networking/v1.
+type extensionsV1beta1IngressManager struct {
+	client kubernetes.Interface
+}
+
+func (m *extensionsV1beta1IngressManager) Get(ctx context.Context, namespace, name string, opts metav1.GetOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.ExtensionsV1beta1().Ingresses(namespace).Get(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertExtensionsV1beta1ToV1(ing), nil
+}
+
+func (m *extensionsV1beta1IngressManager) List(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error) {
+	list, err := m.client.ExtensionsV1beta1().Ingresses(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := &networkingv1.IngressList{ListMeta: list.ListMeta}
+	for i := range list.Items {
+		out.Items = append(out.Items, *convertExtensionsV1beta1ToV1(&list.Items[i]))
+	}
+	return out, nil
+}
+
+func (m *extensionsV1beta1IngressManager) Create(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.CreateOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.ExtensionsV1beta1().Ingresses(namespace).Create(ctx, convertV1ToExtensionsV1beta1(ingress), opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertExtensionsV1beta1ToV1(ing), nil
+}
+
+func (m *extensionsV1beta1IngressManager) Update(ctx context.Context, namespace string, ingress *networkingv1.Ingress, opts metav1.UpdateOptions) (*networkingv1.Ingress, error) {
+	ing, err := m.client.ExtensionsV1beta1().Ingresses(namespace).Update(ctx, convertV1ToExtensionsV1beta1(ingress), opts)
+	if err != nil {
+		return nil, err
+	}
+	return convertExtensionsV1beta1ToV1(ing), nil
+}
+
+func (m *extensionsV1beta1IngressManager) Delete(ctx context.Context, namespace, name string, opts metav1.DeleteOptions) error {
+	return m.client.ExtensionsV1beta1().Ingresses(namespace).Delete(ctx, name, opts)
+}
+
+func (m *extensionsV1beta1IngressManager) IngressPath(obj *networkingv1.Ingress) []string {
+	return ingressPath(obj)
+}
+
+// convertV1beta1ToV1 converts a networking.k8s.io/v1beta1 Ingress to its
+// networking/v1 equivalent. The two types share the same wire shape except
+// that v1 renames Spec.Backend to Spec.DefaultBackend and nests the
+// backend's ServiceName/ServicePort fields under Service.
+func convertV1beta1ToV1(in *networkingv1beta1.Ingress) *networkingv1.Ingress {
+	out := &networkingv1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			DefaultBackend:   convertV1beta1Backend(in.Spec.Backend),
+		},
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	for _, rule := range in.Spec.Rules {
+		out.Spec.Rules = append(out.Spec.Rules, networkingv1.IngressRule{
+			Host:             rule.Host,
+			IngressRuleValue: convertV1beta1RuleValue(rule.IngressRuleValue),
+		})
+	}
+	return out
+}
+
+func convertV1beta1RuleValue(in networkingv1beta1.IngressRuleValue) networkingv1.IngressRuleValue {
+	if in.HTTP == nil {
+		return networkingv1.IngressRuleValue{}
+	}
+	out := &networkingv1.HTTPIngressRuleValue{}
+	for _, p := range in.HTTP.Paths {
+		out.Paths = append(out.Paths, networkingv1.HTTPIngressPath{
+			Path:     p.Path,
+			PathType: (*networkingv1.PathType)(p.PathType),
+			Backend:  *convertV1beta1Backend(&p.Backend),
+		})
+	}
+	return networkingv1.IngressRuleValue{HTTP: out}
+}
+
+func convertV1beta1Backend(in *networkingv1beta1.IngressBackend) *networkingv1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &networkingv1.IngressBackend{Resource: in.Resource}
+	if in.ServiceName != "" {
+		out.Service = &networkingv1.IngressServiceBackend{
+			Name: in.ServiceName,
+			Port: networkingv1.ServiceBackendPort{
+				Name:   in.ServicePort.StrVal,
+				Number: in.ServicePort.IntVal,
+			},
+		}
+	}
+	return out
+}
+
+// convertV1ToV1beta1 converts a networking/v1 Ingress to its
+// networking.k8s.io/v1beta1 equivalent, for writes against older servers.
+func convertV1ToV1beta1(in *networkingv1.Ingress) *networkingv1beta1.Ingress {
+	out := &networkingv1beta1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Spec: networkingv1beta1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			Backend:          convertV1BackendToV1beta1(in.Spec.DefaultBackend),
+		},
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1beta1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	for _, rule := range in.Spec.Rules {
+		v1beta1Rule := networkingv1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			httpRule := &networkingv1beta1.HTTPIngressRuleValue{}
+			for _, p := range rule.HTTP.Paths {
+				httpRule.Paths = append(httpRule.Paths, networkingv1beta1.HTTPIngressPath{
+					Path:     p.Path,
+					PathType: (*networkingv1beta1.PathType)(p.PathType),
+					Backend:  *convertV1BackendToV1beta1(&p.Backend),
+				})
+			}
+			v1beta1Rule.IngressRuleValue = networkingv1beta1.IngressRuleValue{HTTP: httpRule}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, v1beta1Rule)
+	}
+	return out
+}
+
+func convertV1BackendToV1beta1(in *networkingv1.IngressBackend) *networkingv1beta1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &networkingv1beta1.IngressBackend{Resource: in.Resource}
+	if in.Service != nil {
+		out.ServiceName = in.Service.Name
+		if in.Service.Port.Name != "" {
+			out.ServicePort = intstr.FromString(in.Service.Port.Name)
+		} else {
+			out.ServicePort = intstr.FromInt32(in.Service.Port.Number)
+		}
+	}
+	return out
+}
+
+// convertExtensionsV1beta1ToV1 converts an extensions/v1beta1 Ingress to its
+// networking/v1 equivalent. extensions/v1beta1 is wire-compatible with
+// networking.k8s.io/v1beta1, so this reuses the same field mapping.
+func convertExtensionsV1beta1ToV1(in *extensionsv1beta1.Ingress) *networkingv1.Ingress {
+	out := &networkingv1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			DefaultBackend:   convertExtensionsV1beta1Backend(in.Spec.Backend),
+		},
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	for _, rule := range in.Spec.Rules {
+		v1Rule := networkingv1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			httpRule := &networkingv1.HTTPIngressRuleValue{}
+			for _, p := range rule.HTTP.Paths {
+				httpRule.Paths = append(httpRule.Paths, networkingv1.HTTPIngressPath{
+					Path:     p.Path,
+					PathType: (*networkingv1.PathType)(p.PathType),
+					Backend:  *convertExtensionsV1beta1Backend(&p.Backend),
+				})
+			}
+			v1Rule.IngressRuleValue = networkingv1.IngressRuleValue{HTTP: httpRule}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, v1Rule)
+	}
+	return out
+}
+
+func convertExtensionsV1beta1Backend(in *extensionsv1beta1.IngressBackend) *networkingv1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &networkingv1.IngressBackend{Resource: in.Resource}
+	if in.ServiceName != "" {
+		out.Service = &networkingv1.IngressServiceBackend{
+			Name: in.ServiceName,
+			Port: networkingv1.ServiceBackendPort{
+				Name:   in.ServicePort.StrVal,
+				Number: in.ServicePort.IntVal,
+			},
+		}
+	}
+	return out
+}
+
+// convertV1ToExtensionsV1beta1 converts a networking/v1 Ingress to its
+// extensions/v1beta1 equivalent, for writes against servers that have not
+// yet enabled networking.k8s.io.
+func convertV1ToExtensionsV1beta1(in *networkingv1.Ingress) *extensionsv1beta1.Ingress {
+	out := &extensionsv1beta1.Ingress{
+		ObjectMeta: in.ObjectMeta,
+		Spec: extensionsv1beta1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			Backend:          convertV1BackendToExtensionsV1beta1(in.Spec.DefaultBackend),
+		},
+	}
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, extensionsv1beta1.IngressTLS{Hosts: tls.Hosts, SecretName: tls.SecretName})
+	}
+	for _, rule := range in.Spec.Rules {
+		extRule := extensionsv1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			httpRule := &extensionsv1beta1.HTTPIngressRuleValue{}
+			for _, p := range rule.HTTP.Paths {
+				httpRule.Paths = append(httpRule.Paths, extensionsv1beta1.HTTPIngressPath{
+					Path:     p.Path,
+					PathType: (*extensionsv1beta1.PathType)(p.PathType),
+					Backend:  *convertV1BackendToExtensionsV1beta1(&p.Backend),
+				})
+			}
+			extRule.IngressRuleValue = extensionsv1beta1.IngressRuleValue{HTTP: httpRule}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, extRule)
+	}
+	return out
+}
+
+func convertV1BackendToExtensionsV1beta1(in *networkingv1.IngressBackend) *extensionsv1beta1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+	out := &extensionsv1beta1.IngressBackend{Resource: in.Resource}
+	if in.Service != nil {
+		out.ServiceName = in.Service.Name
+		if in.Service.Port.Name != "" {
+			out.ServicePort = intstr.FromString(in.Service.Port.Name)
+		} else {
+			out.ServicePort = intstr.FromInt32(in.Service.Port.Number)
+		}
+	}
+	return out
+}
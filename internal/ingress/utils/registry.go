@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"sync"
+
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// IngressAPI identifies a registered Ingress-like candidate API by name.
+// Built-in candidates are named after their GroupVersion string; out-of-tree
+// candidates registered via Register may use any unique name.
+type IngressAPI string
+
+// OtherAPI is returned by NegotiateIngressAPI when no registered candidate
+// matched.
+const OtherAPI IngressAPI = ""
+
+func (ia IngressAPI) String() string {
+	if ia == OtherAPI {
+		return "unknown API"
+	}
+	return string(ia)
+}
+
+// Built-in candidates, registered by init below.
+const (
+	NetworkingV1      IngressAPI = "networking.k8s.io/v1"
+	NetworkingV1beta1 IngressAPI = "networking.k8s.io/v1beta1"
+	ExtensionsV1beta1 IngressAPI = "extensions/v1beta1"
+)
+
+// ingressCandidate is a registered Ingress-like API: the GroupVersionKind
+// NegotiateIngressAPI probes discovery for, and the factory NewIngressManager
+// uses to build an IngressManager once that candidate is selected.
+type ingressCandidate struct {
+	gvk     schema.GroupVersionKind
+	factory func(dynamic.Interface) IngressManager
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[IngressAPI]ingressCandidate{}
+)
+
+// Register adds name as a candidate that NegotiateIngressAPI can select and
+// NewIngressManager can construct. gvk is the GroupVersionKind discovery
+// probes for; factory builds an IngressManager backed by a dynamic client
+// for resources that have no generated typed client. Re-registering an
+// existing name overwrites it.
+//
+// This lets downstream consumers plug in ingress-shaped CRDs without
+// forking this module: they just need a name, a GVK, and a way to adapt the
+// CRD to IngressManager.
+func Register(name string, gvk schema.GroupVersionKind, factory func(dynamic.Interface) IngressManager) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[IngressAPI(name)] = ingressCandidate{gvk: gvk, factory: factory}
+}
+
+func lookupCandidate(name IngressAPI) (ingressCandidate, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[name]
+	return c, ok
+}
+
+func init() {
+	Register(string(NetworkingV1), networkingv1.SchemeGroupVersion.WithKind("Ingress"), nil)
+	Register(string(NetworkingV1beta1), networkingv1beta1.SchemeGroupVersion.WithKind("Ingress"), nil)
+	Register(string(ExtensionsV1beta1), extensionsv1beta1.SchemeGroupVersion.WithKind("Ingress"), nil)
+}
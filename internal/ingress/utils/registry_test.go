@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+func TestRegisterPluggableCandidate(t *testing.T) {
+	const name = "routing.example.com/v1"
+	gvk := schema.GroupVersionKind{Group: "routing.example.com", Version: "v1", Kind: "RoutingIngress"}
+
+	Register(name, gvk, func(dynamicClient dynamic.Interface) IngressManager {
+		return nil
+	})
+
+	c, ok := lookupCandidate(IngressAPI(name))
+	if !ok {
+		t.Fatalf("lookupCandidate(%q): not found after Register", name)
+	}
+	if c.gvk != gvk {
+		t.Errorf("lookupCandidate(%q) gvk: got %v, want %v", name, c.gvk, gvk)
+	}
+
+	client := &fakeDiscoveryClient{
+		results: map[string]metav1.APIResourceList{
+			"routing.example.com/v1": {APIResources: []metav1.APIResource{{Kind: "RoutingIngress"}}},
+		},
+	}
+
+	got, err := NegotiateIngressAPI(client, []IngressAPI{NetworkingV1, IngressAPI(name)})
+	if err != nil {
+		t.Fatalf("NegotiateIngressAPI: unexpected error: %v", err)
+	}
+	if got != IngressAPI(name) {
+		t.Errorf("NegotiateIngressAPI: got %v, want %v", got, IngressAPI(name))
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// RoutingAPI identifies which family of routing resources a cluster
+// supports, ranging from the legacy Ingress-only API up to the Gateway API.
+type RoutingAPI int
+
+const (
+	OtherRoutingAPI RoutingAPI = iota
+	// LegacyIngress means the cluster supports Ingress but not
+	// IngressClass.
+	LegacyIngress
+	// IngressWithClass means the cluster supports Ingress and
+	// networking.k8s.io/v1 IngressClass.
+	IngressWithClass
+	// GatewayRoutingAPI means the cluster has the Gateway API installed.
+	GatewayRoutingAPI
+)
+
+func (ra RoutingAPI) String() string {
+	switch ra {
+	case LegacyIngress:
+		return "Ingress"
+	case IngressWithClass:
+		return "Ingress+IngressClass"
+	case GatewayRoutingAPI:
+		return "Gateway API"
+	}
+	return "unknown routing API"
+}
+
+// gatewayAPIKinds are the kinds that must all be present at a given
+// gateway.networking.k8s.io GroupVersion for that version to count as
+// supported.
+var gatewayAPIKinds = []string{"GatewayClass", "Gateway", "HTTPRoute"}
+
+// gatewayAPIVersions are the gateway.networking.k8s.io GroupVersions probed
+// by NegotiateRoutingAPI, newest first.
+var gatewayAPIVersions = []schema.GroupVersion{
+	{Group: "gateway.networking.k8s.io", Version: "v1"},
+	{Group: "gateway.networking.k8s.io", Version: "v1beta1"},
+}
+
+// RoutingAPIResult is the outcome of NegotiateRoutingAPI: which RoutingAPI
+// family was selected, and the details needed to act on it.
+type RoutingAPIResult struct {
+	API RoutingAPI
+
+	// IngressAPI is populated when API is LegacyIngress or
+	// IngressWithClass.
+	IngressAPI IngressAPI
+
+	// GatewayAPIVersion is populated when API is GatewayRoutingAPI, and is
+	// the GroupVersion of GatewayClass/Gateway/HTTPRoute the server
+	// supports.
+	GatewayAPIVersion schema.GroupVersion
+}
+
+// NegotiateRoutingAPI generalizes NegotiateIngressAPI to also consider
+// IngressClass and the Gateway API. allowedIngressVersions is passed through
+// to NegotiateIngressAPI for the LegacyIngress and IngressWithClass cases;
+// preference gives the order in which the RoutingAPI families are tried.
+func NegotiateRoutingAPI(client discovery.ServerResourcesInterface, allowedIngressVersions []IngressAPI, preference []RoutingAPI) (RoutingAPIResult, error) {
+	for _, want := range preference {
+		switch want {
+		case GatewayRoutingAPI:
+			if gv, ok := serverHasGatewayAPI(client); ok {
+				return RoutingAPIResult{API: GatewayRoutingAPI, GatewayAPIVersion: gv}, nil
+			}
+		case IngressWithClass:
+			ingressAPI, err := NegotiateIngressAPI(client, allowedIngressVersions)
+			if err != nil {
+				continue
+			}
+			if ok, err := serverHasGVK(client, networkingv1.SchemeGroupVersion.String(), "IngressClass"); err == nil && ok {
+				return RoutingAPIResult{API: IngressWithClass, IngressAPI: ingressAPI}, nil
+			}
+		case LegacyIngress:
+			ingressAPI, err := NegotiateIngressAPI(client, allowedIngressVersions)
+			if err != nil {
+				continue
+			}
+			if ok, err := serverHasGVK(client, networkingv1.SchemeGroupVersion.String(), "IngressClass"); err == nil && ok {
+				continue
+			}
+			return RoutingAPIResult{API: LegacyIngress, IngressAPI: ingressAPI}, nil
+		}
+	}
+	return RoutingAPIResult{}, fmt.Errorf("no suitable routing API found, tried: %v", preference)
+}
+
+// serverHasGatewayAPI returns the newest gateway.networking.k8s.io
+// GroupVersion (per gatewayAPIVersions) for which the server advertises all
+// of gatewayAPIKinds.
+func serverHasGatewayAPI(client discovery.ServerResourcesInterface) (schema.GroupVersion, bool) {
+	for _, gv := range gatewayAPIVersions {
+		hasAll := true
+		for _, kind := range gatewayAPIKinds {
+			ok, err := serverHasGVK(client, gv.String(), kind)
+			if err != nil || !ok {
+				hasAll = false
+				break
+			}
+		}
+		if hasAll {
+			return gv, true
+		}
+	}
+	return schema.GroupVersion{}, false
+}
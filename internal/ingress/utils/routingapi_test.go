@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNegotiateRoutingAPILegacyIngressRequiresNoIngressClass(t *testing.T) {
+	ingressClassClient := &fakeDiscoveryClient{
+		results: map[string]metav1.APIResourceList{
+			"networking.k8s.io/v1": {APIResources: []metav1.APIResource{
+				{Kind: "Ingress"},
+				{Kind: "IngressClass"},
+			}},
+		},
+	}
+
+	got, err := NegotiateRoutingAPI(
+		ingressClassClient,
+		[]IngressAPI{NetworkingV1, NetworkingV1beta1, ExtensionsV1beta1},
+		[]RoutingAPI{LegacyIngress, IngressWithClass},
+	)
+	if err != nil {
+		t.Fatalf("NegotiateRoutingAPI: unexpected error: %v", err)
+	}
+	if got.API != IngressWithClass {
+		t.Errorf("NegotiateRoutingAPI API: got %v, want %v (LegacyIngress must not match when IngressClass is present, even when preferred first)", got.API, IngressWithClass)
+	}
+}
+
+func TestNegotiateRoutingAPI(t *testing.T) {
+	gatewayClient := &fakeDiscoveryClient{
+		results: map[string]metav1.APIResourceList{
+			"gateway.networking.k8s.io/v1": {APIResources: []metav1.APIResource{
+				{Kind: "GatewayClass"},
+				{Kind: "Gateway"},
+				{Kind: "HTTPRoute"},
+			}},
+		},
+	}
+
+	ingressClassClient := &fakeDiscoveryClient{
+		results: map[string]metav1.APIResourceList{
+			"networking.k8s.io/v1": {APIResources: []metav1.APIResource{
+				{Kind: "Ingress"},
+				{Kind: "IngressClass"},
+			}},
+		},
+	}
+
+	legacyClient := &fakeDiscoveryClient{
+		results: map[string]metav1.APIResourceList{
+			"extensions/v1beta1": {APIResources: []metav1.APIResource{
+				{Kind: "Ingress"},
+			}},
+		},
+	}
+
+	emptyClient := &fakeDiscoveryClient{}
+
+	allIngressVersions := []IngressAPI{NetworkingV1, NetworkingV1beta1, ExtensionsV1beta1}
+	fullPreference := []RoutingAPI{GatewayRoutingAPI, IngressWithClass, LegacyIngress}
+
+	for _, tt := range []struct {
+		name   string
+		client *fakeDiscoveryClient
+
+		wantAPI        RoutingAPI
+		wantIngressAPI IngressAPI
+		wantErr        bool
+	}{
+		{
+			name:    "prefers Gateway API when available",
+			client:  gatewayClient,
+			wantAPI: GatewayRoutingAPI,
+		},
+		{
+			name:           "falls back to Ingress+IngressClass",
+			client:         ingressClassClient,
+			wantAPI:        IngressWithClass,
+			wantIngressAPI: NetworkingV1,
+		},
+		{
+			name:           "falls back to legacy Ingress",
+			client:         legacyClient,
+			wantAPI:        LegacyIngress,
+			wantIngressAPI: ExtensionsV1beta1,
+		},
+		{
+			name:    "nothing supported",
+			client:  emptyClient,
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NegotiateRoutingAPI(tt.client, allIngressVersions, fullPreference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NegotiateRoutingAPI: got error %v, wantErr %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.API != tt.wantAPI {
+				t.Errorf("NegotiateRoutingAPI API: got %v, want %v", got.API, tt.wantAPI)
+			}
+			if got.API != GatewayRoutingAPI && got.IngressAPI != tt.wantIngressAPI {
+				t.Errorf("NegotiateRoutingAPI IngressAPI: got %v, want %v", got.IngressAPI, tt.wantIngressAPI)
+			}
+		})
+	}
+}
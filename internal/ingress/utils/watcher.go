@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/discovery"
+	cachedmemory "k8s.io/client-go/discovery/cached/memory"
+)
+
+// maxNegotiationBackoff caps the exponential backoff Watcher applies after
+// consecutive discovery errors.
+const maxNegotiationBackoff = 5 * time.Minute
+
+// Watcher re-negotiates the Ingress API on a cached discovery client at a
+// regular interval, and publishes a notification whenever the negotiated
+// IngressAPI changes (for example when a cluster upgrade removes
+// extensions/v1beta1, or a CRD-based candidate is installed), so that
+// controllers can rebuild their informers against the new GVK without a
+// process restart.
+type Watcher struct {
+	cached          discovery.CachedDiscoveryInterface
+	allowedVersions []IngressAPI
+	interval        time.Duration
+
+	mu        sync.RWMutex
+	current   IngressAPI
+	freshness time.Time
+
+	changes chan IngressAPI
+}
+
+// NewWatcher wraps client in a memcache-style caching discovery client,
+// negotiates the initial IngressAPI, and starts a background goroutine that
+// invalidates the cache and re-negotiates every interval until ctx is done.
+func NewWatcher(ctx context.Context, client discovery.DiscoveryInterface, allowedVersions []IngressAPI, interval time.Duration) (*Watcher, error) {
+	cached := cachedmemory.NewMemCacheClient(client)
+
+	initial, err := NegotiateIngressAPI(cached, allowedVersions)
+	if err != nil {
+		return nil, errors.Wrap(err, "negotiating initial Ingress API")
+	}
+
+	w := &Watcher{
+		cached:          cached,
+		allowedVersions: allowedVersions,
+		interval:        interval,
+		current:         initial,
+		freshness:       time.Now(),
+		changes:         make(chan IngressAPI, 1),
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+// Current returns the most recently negotiated IngressAPI.
+func (w *Watcher) Current() IngressAPI {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Freshness returns when the current IngressAPI was last (re)negotiated.
+func (w *Watcher) Freshness() time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.freshness
+}
+
+// Changes returns a channel that receives the newly negotiated IngressAPI
+// whenever re-negotiation produces a value different from the previous one.
+// It is buffered by one and never closed; it always holds the most recent
+// value, overwriting a stale, unread one rather than dropping the new one,
+// so a slow consumer that drains it eventually catches up to Current()
+// instead of acting on a value that's no longer current.
+func (w *Watcher) Changes() <-chan IngressAPI {
+	return w.changes
+}
+
+// publishChange overwrites any stale, unread value in w.changes with next,
+// so the channel always holds the latest negotiated IngressAPI instead of
+// the first one a slow consumer didn't get around to reading.
+func (w *Watcher) publishChange(next IngressAPI) {
+	for {
+		select {
+		case w.changes <- next:
+			return
+		default:
+		}
+
+		select {
+		case <-w.changes:
+		default:
+		}
+	}
+}
+
+// nextBackoff doubles current, capped at max.
+func nextBackoff(current, max time.Duration) time.Duration {
+	doubled := current * 2
+	if doubled > max {
+		return max
+	}
+	return doubled
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	backoff := w.interval
+
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		w.cached.Invalidate()
+		next, err := NegotiateIngressAPI(w.cached, w.allowedVersions)
+		if err != nil {
+			backoff = nextBackoff(backoff, maxNegotiationBackoff)
+			timer.Reset(backoff)
+			continue
+		}
+		backoff = w.interval
+
+		w.mu.Lock()
+		changed := next != w.current
+		w.current = next
+		w.freshness = time.Now()
+		w.mu.Unlock()
+
+		if changed {
+			w.publishChange(next)
+		}
+
+		timer.Reset(w.interval)
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWatcherPublishChangeCoalescesToLatest(t *testing.T) {
+	w := &Watcher{changes: make(chan IngressAPI, 1)}
+
+	w.publishChange(NetworkingV1)
+	w.publishChange(NetworkingV1beta1)
+	w.publishChange(ExtensionsV1beta1)
+
+	select {
+	case got := <-w.changes:
+		if got != ExtensionsV1beta1 {
+			t.Errorf("Changes(): got %v, want %v (the latest value, not a stale intermediate one)", got, ExtensionsV1beta1)
+		}
+	default:
+		t.Fatal("Changes(): expected a value, got none")
+	}
+
+	select {
+	case got := <-w.changes:
+		t.Errorf("Changes(): expected no further value, got %v", got)
+	default:
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		current time.Duration
+		max     time.Duration
+		want    time.Duration
+	}{
+		{
+			name:    "doubles under the cap",
+			current: 10 * time.Second,
+			max:     time.Minute,
+			want:    20 * time.Second,
+		},
+		{
+			name:    "clamps at the cap",
+			current: 4 * time.Minute,
+			max:     5 * time.Minute,
+			want:    5 * time.Minute,
+		},
+		{
+			name:    "already at the cap",
+			current: 5 * time.Minute,
+			max:     5 * time.Minute,
+			want:    5 * time.Minute,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBackoff(tt.current, tt.max); got != tt.want {
+				t.Errorf("nextBackoff(%v, %v): got %v, want %v", tt.current, tt.max, got, tt.want)
+			}
+		})
+	}
+}